@@ -0,0 +1,52 @@
+// Command alertsink runs the in-cluster Alertmanager webhook forwarder,
+// normalizing incoming alerts and publishing them to the backend selected by
+// flags. It's deployed as the receiver target configured by the
+// `alertsink` Jsonnet component.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/arunvenkatram/kube-prod-runtime/pkg/alertsink"
+)
+
+func main() {
+	var (
+		listenAddr  = flag.String("listen-address", ":8080", "address to listen for Alertmanager webhook POSTs on")
+		backend     = flag.String("backend", "http", "sink backend: one of pubsub, sns, http")
+		pubsubProj  = flag.String("pubsub-project", "", "GCP project for the pubsub backend")
+		pubsubTopic = flag.String("pubsub-topic", "", "GCP pubsub topic for the pubsub backend")
+		snsTopicARN = flag.String("sns-topic-arn", "", "AWS SNS topic ARN for the sns backend")
+		httpURL     = flag.String("http-url", "", "destination URL for the http backend")
+	)
+	flag.Parse()
+
+	ctx := context.Background()
+
+	sink, err := newSink(ctx, *backend, *pubsubProj, *pubsubTopic, *snsTopicARN, *httpURL)
+	if err != nil {
+		logrus.WithError(err).Fatal("configuring alertsink backend")
+	}
+
+	srv := &alertsink.Server{Sink: sink}
+	logrus.WithField("addr", *listenAddr).Info("listening for alertmanager webhooks")
+	logrus.Fatal(http.ListenAndServe(*listenAddr, srv))
+}
+
+func newSink(ctx context.Context, backend, pubsubProj, pubsubTopic, snsTopicARN, httpURL string) (alertsink.Sink, error) {
+	switch backend {
+	case "pubsub":
+		return alertsink.NewPubSubSink(ctx, pubsubProj, pubsubTopic)
+	case "sns":
+		return alertsink.NewSNSSink(snsTopicARN)
+	case "http":
+		return alertsink.NewHTTPSink(httpURL), nil
+	default:
+		return nil, fmt.Errorf("unknown alertsink backend %q", backend)
+	}
+}
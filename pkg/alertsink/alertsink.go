@@ -0,0 +1,91 @@
+// Package alertsink implements a small HTTP service that accepts Alertmanager
+// webhook notifications and republishes them, one message per alert, to a
+// pluggable backend Sink (Pub/Sub, SNS, or a generic HTTP endpoint). It gives
+// operators a durable off-cluster record of every alert firing, since the
+// Alertmanager API itself only exposes current state to in-cluster callers.
+package alertsink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Alert is the stable, sink-facing envelope each inbound Alertmanager alert
+// is normalized into. Its shape is deliberately decoupled from Alertmanager's
+// own webhook payload so that downstream consumers aren't coupled to
+// Alertmanager's wire format.
+type Alert struct {
+	Fingerprint  string            `json:"fingerprint"`
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL"`
+}
+
+// webhookPayload mirrors the JSON body Alertmanager POSTs to a configured
+// `webhook_config` receiver. See:
+// https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type webhookPayload struct {
+	Version  string `json:"version"`
+	GroupKey string `json:"groupKey"`
+	Alerts   []struct {
+		Status       string            `json:"status"`
+		Labels       map[string]string `json:"labels"`
+		Annotations  map[string]string `json:"annotations"`
+		StartsAt     time.Time         `json:"startsAt"`
+		EndsAt       time.Time         `json:"endsAt"`
+		GeneratorURL string            `json:"generatorURL"`
+		Fingerprint  string            `json:"fingerprint"`
+	} `json:"alerts"`
+}
+
+func normalize(payload webhookPayload) []Alert {
+	alerts := make([]Alert, 0, len(payload.Alerts))
+	for _, a := range payload.Alerts {
+		alerts = append(alerts, Alert{
+			Fingerprint:  a.Fingerprint,
+			Status:       a.Status,
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+		})
+	}
+	return alerts
+}
+
+// Server accepts Alertmanager webhook POSTs and publishes each normalized
+// alert to Sink.
+type Server struct {
+	Sink Sink
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload webhookPayload
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		http.Error(w, fmt.Sprintf("decoding webhook payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	for _, alert := range normalize(payload) {
+		if err := s.Sink.Publish(req.Context(), alert); err != nil {
+			logrus.WithError(err).WithField("fingerprint", alert.Fingerprint).Error("publishing alert to sink")
+			http.Error(w, fmt.Sprintf("publishing alert %s: %v", alert.Fingerprint, err), http.StatusBadGateway)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
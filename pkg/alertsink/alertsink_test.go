@@ -0,0 +1,141 @@
+package alertsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSink struct {
+	mu     sync.Mutex
+	alerts []Alert
+	err    error
+}
+
+func (f *fakeSink) Publish(ctx context.Context, alert Alert) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.alerts = append(f.alerts, alert)
+	return nil
+}
+
+func webhookBody(t *testing.T, alerts ...map[string]interface{}) []byte {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{
+		"version":  "4",
+		"groupKey": `{}:{alertname="CrashLooping_test"}`,
+		"alerts":   alerts,
+	})
+	if err != nil {
+		t.Fatalf("marshaling webhook payload: %v", err)
+	}
+	return body
+}
+
+func TestServeHTTPNormalizesAndPublishesAlerts(t *testing.T) {
+	sink := &fakeSink{}
+	server := &Server{Sink: sink}
+
+	body := webhookBody(t, map[string]interface{}{
+		"status":       "firing",
+		"labels":       map[string]string{"alertname": "CrashLooping_test", "namespace": "test-ns", "container": "app"},
+		"annotations":  map[string]string{"summary": "container is crash-looping"},
+		"startsAt":     time.Now().UTC().Format(time.RFC3339),
+		"endsAt":       "0001-01-01T00:00:00Z",
+		"generatorURL": "http://prometheus/graph",
+		"fingerprint":  "abc123",
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ServeHTTP returned status %d, body %q", rec.Code, rec.Body.String())
+	}
+
+	if len(sink.alerts) != 1 {
+		t.Fatalf("got %d published alerts, want 1", len(sink.alerts))
+	}
+	got := sink.alerts[0]
+	if got.Fingerprint != "abc123" {
+		t.Errorf("Fingerprint = %q, want %q", got.Fingerprint, "abc123")
+	}
+	if got.Status != "firing" {
+		t.Errorf("Status = %q, want %q", got.Status, "firing")
+	}
+	if got.Labels["container"] != "app" || got.Labels["namespace"] != "test-ns" {
+		t.Errorf("Labels = %+v, want container=app namespace=test-ns", got.Labels)
+	}
+	if got.Annotations["summary"] != "container is crash-looping" {
+		t.Errorf("Annotations = %+v", got.Annotations)
+	}
+}
+
+func TestServeHTTPPublishesEachAlertInTheGroup(t *testing.T) {
+	sink := &fakeSink{}
+	server := &Server{Sink: sink}
+
+	body := webhookBody(t,
+		map[string]interface{}{"status": "firing", "labels": map[string]string{"container": "a"}, "fingerprint": "1"},
+		map[string]interface{}{"status": "firing", "labels": map[string]string{"container": "b"}, "fingerprint": "2"},
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ServeHTTP returned status %d, body %q", rec.Code, rec.Body.String())
+	}
+	if len(sink.alerts) != 2 {
+		t.Fatalf("got %d published alerts, want 2", len(sink.alerts))
+	}
+}
+
+func TestServeHTTPRejectsNonPost(t *testing.T) {
+	server := &Server{Sink: &fakeSink{}}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestServeHTTPRejectsMalformedBody(t *testing.T) {
+	server := &Server{Sink: &fakeSink{}}
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("not json")))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestServeHTTPReturnsBadGatewayOnSinkError(t *testing.T) {
+	sink := &fakeSink{err: errors.New("backend unavailable")}
+	server := &Server{Sink: sink}
+
+	body := webhookBody(t, map[string]interface{}{"status": "firing", "fingerprint": "abc123"})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,39 @@
+package alertsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// PubSubSink publishes each alert as a message on a Google Cloud Pub/Sub
+// topic.
+type PubSubSink struct {
+	topic *pubsub.Topic
+}
+
+// NewPubSubSink returns a PubSubSink publishing to the given topic in
+// project, creating a client from ambient application-default credentials.
+func NewPubSubSink(ctx context.Context, project, topic string) (*PubSubSink, error) {
+	client, err := pubsub.NewClient(ctx, project)
+	if err != nil {
+		return nil, fmt.Errorf("creating pubsub client: %v", err)
+	}
+	return &PubSubSink{topic: client.Topic(topic)}, nil
+}
+
+func (s *PubSubSink) Publish(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("marshaling alert: %v", err)
+	}
+
+	result := s.topic.Publish(ctx, &pubsub.Message{Data: body})
+	_, err = result.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("publishing to pubsub topic %s: %v", s.topic.ID(), err)
+	}
+	return nil
+}
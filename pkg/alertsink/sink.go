@@ -0,0 +1,51 @@
+package alertsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Sink publishes a single normalized Alert to a backend. Implementations
+// must be safe for concurrent use.
+type Sink interface {
+	Publish(ctx context.Context, alert Alert) error
+}
+
+// HTTPSink publishes each alert as a JSON POST to a fixed URL. It's the
+// generic fallback for backends that aren't Pub/Sub or SNS.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPSink returns an HTTPSink posting to url using http.DefaultClient.
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{URL: url, Client: http.DefaultClient}
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("marshaling alert: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to %s: %v", s.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sink %s returned status %s", s.URL, resp.Status)
+	}
+	return nil
+}
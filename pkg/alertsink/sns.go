@@ -0,0 +1,43 @@
+package alertsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+// SNSSink publishes each alert as a message on an AWS SNS topic.
+type SNSSink struct {
+	client   *sns.SNS
+	topicARN string
+}
+
+// NewSNSSink returns an SNSSink publishing to topicARN, creating a client
+// from the ambient AWS session (environment/shared config/instance role).
+func NewSNSSink(topicARN string) (*SNSSink, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("creating aws session: %v", err)
+	}
+	return &SNSSink{client: sns.New(sess), topicARN: topicARN}, nil
+}
+
+func (s *SNSSink) Publish(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("marshaling alert: %v", err)
+	}
+
+	_, err = s.client.PublishWithContext(ctx, &sns.PublishInput{
+		TopicArn: aws.String(s.topicARN),
+		Message:  aws.String(string(body)),
+	})
+	if err != nil {
+		return fmt.Errorf("publishing to sns topic %s: %v", s.topicARN, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package integration
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/arunvenkatram/kube-prod-runtime/tests/retry"
+)
+
+// alertsinkReceiverURL is the DNS name manifests/components/alertsink.jsonnet
+// gives the alertsink Service by default -- the one thing about the wiring
+// this test can assert on without reaching into Alertmanager's full,
+// version-specific config schema.
+const alertsinkReceiverURL = "http://alertsink.kubeprod.svc:80/"
+
+var _ = Describe("Alertsink", func() {
+	var c kubernetes.Interface
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = kubernetes.NewForConfigOrDie(clusterConfigOrDie())
+	})
+
+	Context("wiring", func() {
+		// Nothing can make Alertmanager POST a webhook at a test process:
+		// the receiver it calls is whatever was merged into its config at
+		// install time (manifests/components/alertsink.jsonnet's
+		// AlertmanagerReceiver), and that can never be this test's own
+		// address. The regression this suite actually needs to catch is a
+		// broken or missing Jsonnet receiver config, so assert on that
+		// directly: that Alertmanager's live config still names alertsink's
+		// Service as a webhook receiver. (pkg/alertsink has its own unit
+		// tests for the decode/normalize/publish logic itself, which don't
+		// need a cluster at all.)
+		//
+		// Alertmanager's /api/v1/status response embeds its config as a
+		// YAML string rather than structured JSON, and that embedding isn't
+		// pinned across Alertmanager versions -- so this checks for the
+		// receiver URL as a raw substring of the response instead of
+		// decoding it, which is both simpler and more robust to that drift.
+		It("configures Alertmanager with the alertsink webhook receiver", func() {
+			Eventually(func() (string, error) {
+				resultRaw, err := retry.ProxyGetWithRetry(ctx, func() ([]byte, error) {
+					return c.CoreV1().Services("kubeprod").ProxyGet("http", "alertmanager", "9093", am_path+"/api/v1/status", nil).DoRaw()
+				})
+				return string(resultRaw), err
+			}, "2m", "5s").Should(ContainSubstring(alertsinkReceiverURL))
+		})
+	})
+})
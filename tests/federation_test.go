@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/arunvenkatram/kube-prod-runtime/tests/retry"
+)
+
+// remoteWriteURLEnv names the environment variable giving the remote_write
+// URL this cluster's Prometheus was installed with (the `remoteWrite.url`
+// Jsonnet parameter, set at install time) -- there's no fixed default to
+// assert against, since the endpoint is necessarily external to the
+// cluster and specific to each environment.
+const remoteWriteURLEnv = "KUBEPROD_TEST_REMOTE_WRITE_URL"
+
+var _ = Describe("Monitoring", func() {
+	var c kubernetes.Interface
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = kubernetes.NewForConfigOrDie(clusterConfigOrDie())
+	})
+
+	Context("federation", func() {
+		// Prometheus's remote_write target is a manifest-time parameter
+		// (see manifests/components/prometheus-remote-write.jsonnet), set
+		// once at install against a real external endpoint this test can't
+		// reconfigure or reach from in here. So rather than faking delivery
+		// against an in-test receiver Prometheus was never told about
+		// (which proves nothing), this checks that the install's live
+		// config actually contains the remote_write URL it was supposed to
+		// be wired with -- the Jsonnet-wiring regression this suite exists
+		// to catch. tests/remotewrite has its own unit tests for the
+		// decode logic itself, which don't need a cluster at all.
+		It("wires Prometheus to the configured remote_write endpoint", func() {
+			url := os.Getenv(remoteWriteURLEnv)
+			if url == "" {
+				Skip(remoteWriteURLEnv + " not set; nothing to assert the cluster was installed with")
+			}
+
+			Eventually(func() (string, error) {
+				resultRaw, err := retry.ProxyGetWithRetry(ctx, func() ([]byte, error) {
+					return c.CoreV1().Services("kubeprod").ProxyGet("http", "prometheus", "9090", "api/v1/status/config", nil).DoRaw()
+				})
+				return string(resultRaw), err
+			}, "2m", "5s").Should(ContainSubstring(url))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -8,8 +9,12 @@ import (
 	. "github.com/onsi/gomega"
 
 	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/arunvenkatram/kube-prod-runtime/tests/prom"
+	"github.com/arunvenkatram/kube-prod-runtime/tests/retry"
 )
 
 const am_path = "/alertmanager"
@@ -62,12 +67,22 @@ func countAlerts(alerts []alert) int {
 	return len(alerts)
 }
 
+// deleteNsWithRetry deletes ns, retrying through transient control-plane
+// hiccups with the retry package, instead of the bare, unretried deleteNs.
+func deleteNsWithRetry(ctx context.Context, c kubernetes.Interface, ns string) {
+	Expect(retry.DeleteK8sObjectWithRetry(ctx, func() error {
+		return c.CoreV1().Namespaces().Delete(ns, nil)
+	})).To(Succeed())
+}
+
 var _ = Describe("Monitoring", func() {
 	var c kubernetes.Interface
 	var deploy *appsv1beta1.Deployment
 	var ns string
+	var ctx context.Context
 
 	BeforeEach(func() {
+		ctx = context.Background()
 		c = kubernetes.NewForConfigOrDie(clusterConfigOrDie())
 		ns = createNsOrDie(c.CoreV1(), "test-monitoring-")
 		decoder := scheme.Codecs.UniversalDeserializer()
@@ -75,47 +90,29 @@ var _ = Describe("Monitoring", func() {
 	})
 
 	AfterEach(func() {
-		deleteNs(c.CoreV1(), ns)
+		deleteNsWithRetry(ctx, c, ns)
 	})
 
 	JustBeforeEach(func() {
-		var err error
-		deploy, err = c.AppsV1beta1().Deployments(ns).Create(deploy)
+		obj, err := retry.CreateK8sObjectWithRetry(ctx, func() (interface{}, error) {
+			return c.AppsV1beta1().Deployments(ns).Create(deploy)
+		}, func() (interface{}, error) {
+			return c.AppsV1beta1().Deployments(ns).Get(deploy.Name, metav1.GetOptions{})
+		})
 		Expect(err).NotTo(HaveOccurred())
+		deploy = obj.(*appsv1beta1.Deployment)
 	})
 
 	Context("basic", func() {
-		// This test makes a query to the prometheus API to check if prometheus is
-		// monitoring the container launched by the test.
-		It("should monitor container", func() {
-			var series []Series
-			Eventually(func() ([]Series, error) {
-				selector := fmt.Sprintf("kube_pod_container_info{namespace=\"%s\",container=\"%s\"}", ns, deploy.Spec.Template.Spec.Containers[0].Name)
-				params := map[string]string{"match[]": selector}
-				resultRaw, err := c.CoreV1().Services("kubeprod").ProxyGet("http", "prometheus", "9090", "api/v1/series", params).DoRaw()
-				if err != nil {
-					return nil, err
-				}
-
-				resp := promResponse{}
-				json.Unmarshal(resultRaw, &resp)
-				json.Unmarshal(resp.Data, &series)
-
-				return series, err
-			}, "20m", "5s").
-				Should(WithTransform(countSeries, BeNumerically(">", 0)))
-
-			Expect(series[0].Container).To(Equal(deploy.Spec.Template.Spec.Containers[0].Name))
-			Expect(series[0].Namespace).To(Equal(ns))
-		})
-
 		// This test queries the prometheus api to check if the alertmanagers
 		// are auto-discovered
 		It("should discover alertmanagers in the cluster", func() {
 			var managers alertmanager
 			Eventually(func() ([]endpoint, error) {
 				params := map[string]string{}
-				resultRaw, err := c.CoreV1().Services("kubeprod").ProxyGet("http", "prometheus", "9090", "api/v1/alertmanagers", params).DoRaw()
+				resultRaw, err := retry.ProxyGetWithRetry(ctx, func() ([]byte, error) {
+					return c.CoreV1().Services("kubeprod").ProxyGet("http", "prometheus", "9090", "api/v1/alertmanagers", params).DoRaw()
+				})
 				if err != nil {
 					return nil, err
 				}
@@ -135,32 +132,34 @@ var _ = Describe("Monitoring", func() {
 	Context("a CrashLoop", func() {
 		BeforeEach(func() {
 			deploy.Spec.Template.Spec.Containers[0].Command = []string{"echo"}
-		})
 
-		// In this test we configure the container such that it enters a CrashLoop
-		// The test passes successfully if prometheus reports that the container
-		// has entered a CrashLoop
-		It("should detect the crashing container", func() {
-			var series []Series
-			Eventually(func() ([]Series, error) {
-				selector := fmt.Sprintf("ALERTS{namespace=\"%s\",container=\"%s\",alertname=\"%s\",alertstate=\"firing\"}", ns, deploy.Spec.Template.Spec.Containers[0].Name, am_alertname)
-				params := map[string]string{"match[]": selector}
-				resultRaw, err := c.CoreV1().Services("kubeprod").ProxyGet("http", "prometheus", "9090", "api/v1/series", params).DoRaw()
+			// Scraping can lag node readiness by a scrape interval or two
+			// right after cluster creation; give it a chance to catch up so
+			// a slow scrape config doesn't get mistaken for the CrashLoop
+			// alert/restart-rate not firing below. Scoped to this context,
+			// not the package's shared setup, since it's only the CrashLoop
+			// assertions below that depend on it.
+			nodesUp := func() (bool, error) {
+				vec, err := prom.QueryInstant(c, `up{job="kubernetes-nodes"}`)
 				if err != nil {
-					return nil, err
+					return false, err
 				}
-
-				resp := promResponse{}
-				json.Unmarshal(resultRaw, &resp)
-				json.Unmarshal(resp.Data, &series)
-
-				return series, err
-			}, "20m", "5s").
-				Should(WithTransform(countSeries, BeNumerically(">", 0)))
-
-			Expect(series[0].Container).To(Equal(deploy.Spec.Template.Spec.Containers[0].Name))
-			Expect(series[0].Namespace).To(Equal(ns))
-			Expect(series[0].Alertname).To(Equal(am_alertname))
+				if len(vec) == 0 {
+					return false, nil
+				}
+				for _, sample := range vec {
+					if sample.Value != 1 {
+						return false, nil
+					}
+				}
+				return true, nil
+			}
+			Eventually(nodesUp, "5m", "5s").Should(BeTrue())
+
+			// A single passing scrape can be a fluke (e.g. a node flapping
+			// through NotReady right as we sampled it); make sure "up" holds
+			// for a real stretch before trusting it.
+			Consistently(nodesUp, "30s", "5s").Should(BeTrue())
 		})
 
 		// In this test we test if the alertmanager api reports the CrashLooping container
@@ -169,7 +168,9 @@ var _ = Describe("Monitoring", func() {
 			Eventually(func() ([]alert, error) {
 				filter := fmt.Sprintf("\"namespace=%s\",\"container=%s\",\"alertname=%s\"}", ns, deploy.Spec.Template.Spec.Containers[0].Name, am_alertname)
 				params := map[string]string{"active": "true", "filter": filter}
-				resultRaw, err := c.CoreV1().Services("kubeprod").ProxyGet("http", "alertmanager", "9093", am_path+"/api/v1/alerts", params).DoRaw()
+				resultRaw, err := retry.ProxyGetWithRetry(ctx, func() ([]byte, error) {
+					return c.CoreV1().Services("kubeprod").ProxyGet("http", "alertmanager", "9093", am_path+"/api/v1/alerts", params).DoRaw()
+				})
 				if err != nil {
 					return nil, err
 				}
@@ -186,5 +187,38 @@ var _ = Describe("Monitoring", func() {
 			Expect(alerts[0].Label.Namespace).To(Equal(ns))
 			Expect(alerts[0].Label.Alertname).To(Equal(am_alertname))
 		})
+
+		// Beyond "the series exists", the restart rate kube-state-metrics
+		// reports for the crashing container should be strictly positive
+		// and sane: this catches a scrape config or recording rule
+		// regression that a bare series-exists check wouldn't, e.g. a
+		// relabeling rule that silently zeroes counters.
+		It("reports a sane restart rate", func() {
+			var restartRate float64
+			query := fmt.Sprintf(`rate(kube_pod_container_status_restarts_total{namespace="%s",container="%s"}[2m])`, ns, deploy.Spec.Template.Spec.Containers[0].Name)
+
+			Eventually(func() (float64, error) {
+				vec, err := prom.QueryInstant(c, query)
+				if err != nil {
+					return 0, err
+				}
+				if len(vec) == 0 {
+					return 0, nil
+				}
+				restartRate = float64(vec[0].Value)
+				return restartRate, nil
+			}, "20m", "5s").Should(BeNumerically(">", 0))
+
+			// CrashLoopBackOff's restart delay grows exponentially (10s,
+			// 20s, 40s, ... capped at 5m), so there's no steady-state rate
+			// to pin a single expected value against -- the true rate
+			// depends entirely on where in that ramp the 2m window we
+			// queried landed. What does hold everywhere in the schedule is
+			// the 10s floor on the delay, which bounds the rate from
+			// above: it can never exceed one restart per 10s, however
+			// early we sample.
+			const maxRestartRate = 1.0 / 10
+			Expect(restartRate).To(BeNumerically("<=", maxRestartRate))
+		})
 	})
 })
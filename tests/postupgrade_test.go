@@ -0,0 +1,160 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/arunvenkatram/kube-prod-runtime/tests/retry"
+)
+
+// Environment variables controlling the post-upgrade alert gate below. These
+// are deliberately configurable (rather than hardcoded) so the settle period
+// and allow-list can be tuned per-CI-environment without touching the test.
+const (
+	settleDurationEnv = "KUBEPROD_TEST_ALERT_SETTLE_DURATION"
+	allowlistPathEnv  = "KUBEPROD_TEST_ALERT_ALLOWLIST"
+)
+
+const defaultSettleDuration = 5 * time.Minute
+
+// promSample is a single sample of a Prometheus instant-vector result, i.e.
+// one entry of `data.result` from a vector-typed api/v1/query response:
+// {"metric": {...}, "value": [<ts>, "<value>"]}.
+type promSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]interface{}    `json:"value"`
+}
+
+type promVectorData struct {
+	ResultType string       `json:"resultType"`
+	Result     []promSample `json:"result"`
+}
+
+// decodeVector unmarshals a promResponse whose `data` field holds a
+// vector-typed result, returning the individual samples.
+func (r promResponse) decodeVector() ([]promSample, error) {
+	var data promVectorData
+	if err := json.Unmarshal(r.Data, &data); err != nil {
+		return nil, err
+	}
+	if data.ResultType != "vector" {
+		return nil, fmt.Errorf("expected prometheus resultType \"vector\", got %q", data.ResultType)
+	}
+	return data.Result, nil
+}
+
+// defaultAllowedCriticalAlerts are always-on canaries (e.g. the Watchdog
+// alert used to verify the alerting pipeline itself is alive) that are
+// expected to be firing on every healthy cluster, upgraded or not.
+var defaultAllowedCriticalAlerts = []string{"Watchdog"}
+
+// criticalAlertAllowlist names alerts that are allowed to be firing
+// immediately after an upgrade, e.g. always-on canaries like Watchdog.
+type criticalAlertAllowlist struct {
+	Alerts []string `json:"alerts"`
+}
+
+func (a criticalAlertAllowlist) allows(name string) bool {
+	for _, allowed := range a.Alerts {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loadCriticalAlertAllowlist reads a JSON allow-list of the form
+// {"alerts": ["Watchdog"]} from path, merging it with
+// defaultAllowedCriticalAlerts. An empty path yields just the defaults
+// rather than an error, so the gate doesn't fail the first real cluster it
+// runs against before anyone's had a chance to configure it.
+func loadCriticalAlertAllowlist(path string) (criticalAlertAllowlist, error) {
+	allow := criticalAlertAllowlist{Alerts: append([]string{}, defaultAllowedCriticalAlerts...)}
+	if path == "" {
+		return allow, nil
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return allow, err
+	}
+	if err := json.Unmarshal(buf, &allow); err != nil {
+		return allow, err
+	}
+	allow.Alerts = append(allow.Alerts, defaultAllowedCriticalAlerts...)
+	return allow, nil
+}
+
+func settleDuration() time.Duration {
+	if raw := os.Getenv(settleDurationEnv); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil {
+			return d
+		}
+	}
+	return defaultSettleDuration
+}
+
+var _ = Describe("Monitoring", func() {
+	var c kubernetes.Interface
+	var ctx context.Context
+
+	Context("post-upgrade", func() {
+		BeforeEach(func() {
+			ctx = context.Background()
+			c = kubernetes.NewForConfigOrDie(clusterConfigOrDie())
+		})
+
+		// After `kubeprod install`/`kubeprod upgrade`, the shipped alert
+		// rules should never leave critical alerts firing against an
+		// otherwise healthy, freshly-upgraded cluster. This mirrors the OTA
+		// upgrade-alert gate and catches regressions in the alert rules
+		// that the CrashLoop tests in monitoring_test.go wouldn't notice.
+		It("should not leave critical alerts firing", func() {
+			time.Sleep(settleDuration())
+
+			allow, err := loadCriticalAlertAllowlist(os.Getenv(allowlistPathEnv))
+			Expect(err).NotTo(HaveOccurred())
+
+			params := map[string]string{"query": `max_over_time(ALERTS{alertstate="firing",severity="critical"}[1m])`}
+
+			var samples []promSample
+			Eventually(func() error {
+				resultRaw, err := retry.ProxyGetWithRetry(ctx, func() ([]byte, error) {
+					return c.CoreV1().Services("kubeprod").ProxyGet("http", "prometheus", "9090", "api/v1/query", params).DoRaw()
+				})
+				if err != nil {
+					return err
+				}
+
+				resp := promResponse{}
+				if err := json.Unmarshal(resultRaw, &resp); err != nil {
+					return err
+				}
+				samples, err = resp.decodeVector()
+				return err
+			}, "2m", "5s").Should(Succeed())
+
+			var unexpected []string
+			for _, s := range samples {
+				name := s.Metric["alertname"]
+				if allow.allows(name) {
+					continue
+				}
+				unexpected = append(unexpected, fmt.Sprintf("%s (namespace=%s, value=%s)", name, s.Metric["namespace"], fmt.Sprint(s.Value[1])))
+			}
+
+			if len(unexpected) > 0 {
+				Fail(fmt.Sprintf("%d critical alert(s) firing after upgrade: %s", len(unexpected), strings.Join(unexpected, "; ")))
+			}
+		})
+	})
+})
@@ -0,0 +1,48 @@
+// Package prom provides reusable helpers for querying Prometheus, for
+// integration tests that need to validate actual SLI values rather than
+// just "series exists".
+package prom
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/prometheus/common/model"
+	"k8s.io/client-go/kubernetes"
+)
+
+type response struct {
+	Status string          `json:"status"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// QueryInstant runs query against the kubeprod Prometheus's api/v1/query
+// endpoint, proxied through the API server, and decodes the result as an
+// instant vector.
+func QueryInstant(c kubernetes.Interface, query string) (model.Vector, error) {
+	params := map[string]string{"query": query}
+	resultRaw, err := c.CoreV1().Services("kubeprod").ProxyGet("http", "prometheus", "9090", "api/v1/query", params).DoRaw()
+	if err != nil {
+		return nil, fmt.Errorf("querying prometheus: %v", err)
+	}
+
+	var resp response
+	if err := json.Unmarshal(resultRaw, &resp); err != nil {
+		return nil, fmt.Errorf("decoding prometheus response: %v", err)
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("prometheus query %q failed: status %s", query, resp.Status)
+	}
+	if resp.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("prometheus query %q returned resultType %q, want vector", query, resp.Data.ResultType)
+	}
+
+	var vec model.Vector
+	if err := json.Unmarshal(resp.Data.Result, &vec); err != nil {
+		return nil, fmt.Errorf("decoding vector result: %v", err)
+	}
+	return vec, nil
+}
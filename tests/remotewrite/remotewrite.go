@@ -0,0 +1,118 @@
+// Package remotewrite provides a minimal fake receiver for the Prometheus
+// remote_write protocol, for use by integration tests that assert a kubeprod
+// Prometheus correctly forwards samples to an external long-term-storage
+// endpoint (Cortex/Thanos/Mimir-compatible) instead of, or in addition to,
+// scraping them locally.
+package remotewrite
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/gogo/protobuf/proto"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// Sample is a single decoded remote_write time series sample.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+	// TimestampMs is milliseconds since the Unix epoch, as sent on the wire.
+	TimestampMs int64
+}
+
+// Receiver is an http.Handler implementing the write side of the Prometheus
+// remote_write protocol: snappy-compressed protobuf WriteRequest bodies,
+// POSTed to it, are decoded and appended to an in-memory buffer that tests
+// can query.
+type Receiver struct {
+	mu      sync.Mutex
+	samples []Sample
+}
+
+// NewReceiver returns an empty Receiver.
+func NewReceiver() *Receiver {
+	return &Receiver{}
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	compressed, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	samples, err := Decode(compressed)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("decoding remote_write request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	r.samples = append(r.samples, samples...)
+	r.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Samples returns every sample received so far whose labels are a superset
+// of match.
+func (r *Receiver) Samples(match map[string]string) []Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []Sample
+	for _, s := range r.samples {
+		if labelsMatch(s.Labels, match) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+func labelsMatch(labels, match map[string]string) bool {
+	for k, v := range match {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Decode snappy-decompresses and protobuf-unmarshals body as a Prometheus
+// remote_write WriteRequest, flattening it into individual Samples.
+func Decode(body []byte) ([]Sample, error) {
+	decompressed, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("snappy decompress: %v", err)
+	}
+
+	var req prompb.WriteRequest
+	if err := proto.Unmarshal(decompressed, &req); err != nil {
+		return nil, fmt.Errorf("protobuf unmarshal: %v", err)
+	}
+
+	var samples []Sample
+	for _, ts := range req.Timeseries {
+		labels := make(map[string]string, len(ts.Labels))
+		for _, l := range ts.Labels {
+			labels[l.Name] = l.Value
+		}
+		for _, s := range ts.Samples {
+			samples = append(samples, Sample{
+				Labels:      labels,
+				Value:       s.Value,
+				TimestampMs: s.Timestamp,
+			})
+		}
+	}
+	return samples, nil
+}
@@ -0,0 +1,92 @@
+package remotewrite
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+func encodeWriteRequest(t *testing.T, labels map[string]string, value float64, timestampMs int64) []byte {
+	t.Helper()
+
+	pbLabels := make([]prompb.Label, 0, len(labels))
+	for name, v := range labels {
+		pbLabels = append(pbLabels, prompb.Label{Name: name, Value: v})
+	}
+
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels:  pbLabels,
+			Samples: []prompb.Sample{{Value: value, Timestamp: timestampMs}},
+		}},
+	}
+
+	body, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshaling write request: %v", err)
+	}
+	return snappy.Encode(nil, body)
+}
+
+func TestServeHTTPDecodesAndBuffersSamples(t *testing.T) {
+	receiver := NewReceiver()
+	labels := map[string]string{"__name__": "up", "job": "kubernetes-nodes"}
+
+	body := encodeWriteRequest(t, labels, 1, 1234)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	receiver.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ServeHTTP returned status %d, body %q", rec.Code, rec.Body.String())
+	}
+
+	got := receiver.Samples(labels)
+	if len(got) != 1 {
+		t.Fatalf("got %d samples, want 1", len(got))
+	}
+	if got[0].Value != 1 || got[0].TimestampMs != 1234 {
+		t.Errorf("got sample %+v", got[0])
+	}
+}
+
+func TestSamplesFiltersByLabelSubset(t *testing.T) {
+	receiver := NewReceiver()
+	body := encodeWriteRequest(t, map[string]string{"__name__": "up", "job": "a"}, 1, 0)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	receiver.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := receiver.Samples(map[string]string{"job": "b"}); len(got) != 0 {
+		t.Errorf("Samples matched a non-matching label set: %+v", got)
+	}
+	if got := receiver.Samples(map[string]string{"job": "a"}); len(got) != 1 {
+		t.Errorf("got %d samples matching job=a, want 1", len(got))
+	}
+}
+
+func TestServeHTTPRejectsNonPost(t *testing.T) {
+	receiver := NewReceiver()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	receiver.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestServeHTTPRejectsUndecodableBody(t *testing.T) {
+	receiver := NewReceiver()
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("not a valid payload")))
+	rec := httptest.NewRecorder()
+	receiver.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,133 @@
+// Package retry wraps the Kubernetes API calls the integration suite makes
+// directly (Create/Get/Delete, proxied raw GETs) with capped exponential
+// backoff, so a transient control-plane hiccup during the heavy scheduling
+// churn the CrashLoop tests induce doesn't get mistaken for a real failure.
+package retry
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// defaultBackoff starts at 500ms between attempts and doubles. wait.Backoff
+// stops retrying as soon as the doubled delay would pass Cap (15s), so this
+// makes at most 5 attempts over about 15s, before all 7 Steps are used.
+var defaultBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2.0,
+	Steps:    7,
+	Cap:      15 * time.Second,
+}
+
+// isRetryable reports whether err looks like a transient control-plane or
+// network hiccup rather than a real failure: connection resets, EOF, 429s,
+// and 5xxs.
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if err == io.EOF || strings.Contains(err.Error(), "EOF") {
+		return true
+	}
+	if strings.Contains(err.Error(), "use of closed network connection") || strings.Contains(err.Error(), "connection reset by peer") {
+		return true
+	}
+	if apierrors.IsTooManyRequests(err) {
+		return true
+	}
+	if apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) {
+		return true
+	}
+	if statusErr, ok := err.(apierrors.APIStatus); ok {
+		if code := statusErr.Status().Code; code >= 500 && code < 600 {
+			return true
+		}
+	}
+	return false
+}
+
+// do retries fn with capped exponential backoff until it succeeds, ctx is
+// done, or fn returns a non-retryable error.
+func do(ctx context.Context, fn func() error) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(defaultBackoff, func() (bool, error) {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
+		lastErr = fn()
+		if lastErr == nil {
+			return true, nil
+		}
+		if isRetryable(lastErr) {
+			return false, nil
+		}
+		return false, lastErr
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return lastErr
+	}
+	return err
+}
+
+// CreateK8sObjectWithRetry retries create until it succeeds, returning its
+// result, or gives up on a non-retryable error or exhausted backoff.
+//
+// A retried create can land on the server even though the client never saw
+// the response (e.g. the connection was reset right after the apiserver
+// committed the object): the next attempt then sees an AlreadyExists that
+// isn't a real conflict. get is used to fetch that already-created object
+// instead of treating the retry as failed; pass nil if no such fallback is
+// available.
+func CreateK8sObjectWithRetry(ctx context.Context, create func() (interface{}, error), get func() (interface{}, error)) (interface{}, error) {
+	var obj interface{}
+	attempt := 0
+	err := do(ctx, func() error {
+		var err error
+		obj, err = create()
+		if err != nil && attempt > 0 && get != nil && apierrors.IsAlreadyExists(err) {
+			obj, err = get()
+		}
+		attempt++
+		return err
+	})
+	return obj, err
+}
+
+// GetK8sObjectWithRetry retries get until it succeeds, returning its result,
+// or gives up on a non-retryable error or exhausted backoff.
+func GetK8sObjectWithRetry(ctx context.Context, get func() (interface{}, error)) (interface{}, error) {
+	var obj interface{}
+	err := do(ctx, func() error {
+		var err error
+		obj, err = get()
+		return err
+	})
+	return obj, err
+}
+
+// DeleteK8sObjectWithRetry retries del until it succeeds, or gives up on a
+// non-retryable error or exhausted backoff.
+func DeleteK8sObjectWithRetry(ctx context.Context, del func() error) error {
+	return do(ctx, del)
+}
+
+// ProxyGetWithRetry retries a proxied raw GET (e.g. ProxyGet(...).DoRaw())
+// until it succeeds, returning its body, or gives up on a non-retryable
+// error or exhausted backoff.
+func ProxyGetWithRetry(ctx context.Context, proxyGet func() ([]byte, error)) ([]byte, error) {
+	var body []byte
+	err := do(ctx, func() error {
+		var err error
+		body, err = proxyGet()
+		return err
+	})
+	return body, err
+}
@@ -0,0 +1,296 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/arunvenkatram/kube-prod-runtime/tests/retry"
+)
+
+// workloadUnderTest abstracts create/delete/pod-selection over the core
+// workload kinds kube-state-metrics (and hence kubeprod's alert rules)
+// watches, so the monitoring assertions below can run unchanged against
+// each of them.
+type workloadUnderTest interface {
+	Create(ctx context.Context, c kubernetes.Interface, ns string) error
+	Delete(ctx context.Context, c kubernetes.Interface, ns string) error
+	SetCrashLoop()
+	PodSelector() string
+	ContainerName() string
+}
+
+// ParseType maps a workload kind string, as found in a fixture's `kind`
+// field, onto the supported kinds below.
+func ParseType(kind string) (string, error) {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet":
+		return kind, nil
+	default:
+		return "", fmt.Errorf("unsupported workload kind %q", kind)
+	}
+}
+
+// newWorkloadUnderTest loads the fixture for kind and wraps it in the
+// matching workloadUnderTest implementation.
+func newWorkloadUnderTest(kind string) (workloadUnderTest, error) {
+	kind, err := ParseType(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := scheme.Codecs.UniversalDeserializer()
+	switch kind {
+	case "Deployment":
+		return &deploymentWorkload{obj: decodeFileOrDie(decoder, "testdata/monitoring-deploy.yaml").(*appsv1beta1.Deployment)}, nil
+	case "StatefulSet":
+		return &statefulSetWorkload{obj: decodeFileOrDie(decoder, "testdata/monitoring-statefulset.yaml").(*appsv1.StatefulSet)}, nil
+	case "DaemonSet":
+		return &daemonSetWorkload{obj: decodeFileOrDie(decoder, "testdata/monitoring-daemonset.yaml").(*appsv1.DaemonSet)}, nil
+	case "ReplicaSet":
+		return &replicaSetWorkload{obj: decodeFileOrDie(decoder, "testdata/monitoring-replicaset.yaml").(*appsv1.ReplicaSet)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %q", kind)
+	}
+}
+
+type deploymentWorkload struct{ obj *appsv1beta1.Deployment }
+
+func (w *deploymentWorkload) Create(ctx context.Context, c kubernetes.Interface, ns string) error {
+	obj, err := retry.CreateK8sObjectWithRetry(ctx, func() (interface{}, error) {
+		return c.AppsV1beta1().Deployments(ns).Create(w.obj)
+	}, func() (interface{}, error) {
+		return c.AppsV1beta1().Deployments(ns).Get(w.obj.Name, metav1.GetOptions{})
+	})
+	if err != nil {
+		return err
+	}
+	w.obj = obj.(*appsv1beta1.Deployment)
+	return nil
+}
+func (w *deploymentWorkload) Delete(ctx context.Context, c kubernetes.Interface, ns string) error {
+	// apps/v1beta1 orphans a Deployment's ReplicaSets (and so its pods) by
+	// default; ask for background deletion so the pods go too.
+	policy := metav1.DeletePropagationBackground
+	return retry.DeleteK8sObjectWithRetry(ctx, func() error {
+		return c.AppsV1beta1().Deployments(ns).Delete(w.obj.Name, &metav1.DeleteOptions{PropagationPolicy: &policy})
+	})
+}
+func (w *deploymentWorkload) SetCrashLoop() {
+	w.obj.Spec.Template.Spec.Containers[0].Command = []string{"echo"}
+}
+func (w *deploymentWorkload) PodSelector() string {
+	return metav1.FormatLabelSelector(w.obj.Spec.Selector)
+}
+func (w *deploymentWorkload) ContainerName() string {
+	return w.obj.Spec.Template.Spec.Containers[0].Name
+}
+
+type statefulSetWorkload struct{ obj *appsv1.StatefulSet }
+
+func (w *statefulSetWorkload) Create(ctx context.Context, c kubernetes.Interface, ns string) error {
+	obj, err := retry.CreateK8sObjectWithRetry(ctx, func() (interface{}, error) {
+		return c.AppsV1().StatefulSets(ns).Create(w.obj)
+	}, func() (interface{}, error) {
+		return c.AppsV1().StatefulSets(ns).Get(w.obj.Name, metav1.GetOptions{})
+	})
+	if err != nil {
+		return err
+	}
+	w.obj = obj.(*appsv1.StatefulSet)
+	return nil
+}
+func (w *statefulSetWorkload) Delete(ctx context.Context, c kubernetes.Interface, ns string) error {
+	return retry.DeleteK8sObjectWithRetry(ctx, func() error {
+		return c.AppsV1().StatefulSets(ns).Delete(w.obj.Name, nil)
+	})
+}
+func (w *statefulSetWorkload) SetCrashLoop() {
+	w.obj.Spec.Template.Spec.Containers[0].Command = []string{"echo"}
+}
+func (w *statefulSetWorkload) PodSelector() string {
+	return metav1.FormatLabelSelector(w.obj.Spec.Selector)
+}
+func (w *statefulSetWorkload) ContainerName() string {
+	return w.obj.Spec.Template.Spec.Containers[0].Name
+}
+
+type daemonSetWorkload struct{ obj *appsv1.DaemonSet }
+
+func (w *daemonSetWorkload) Create(ctx context.Context, c kubernetes.Interface, ns string) error {
+	obj, err := retry.CreateK8sObjectWithRetry(ctx, func() (interface{}, error) {
+		return c.AppsV1().DaemonSets(ns).Create(w.obj)
+	}, func() (interface{}, error) {
+		return c.AppsV1().DaemonSets(ns).Get(w.obj.Name, metav1.GetOptions{})
+	})
+	if err != nil {
+		return err
+	}
+	w.obj = obj.(*appsv1.DaemonSet)
+	return nil
+}
+func (w *daemonSetWorkload) Delete(ctx context.Context, c kubernetes.Interface, ns string) error {
+	return retry.DeleteK8sObjectWithRetry(ctx, func() error {
+		return c.AppsV1().DaemonSets(ns).Delete(w.obj.Name, nil)
+	})
+}
+func (w *daemonSetWorkload) SetCrashLoop() {
+	w.obj.Spec.Template.Spec.Containers[0].Command = []string{"echo"}
+}
+func (w *daemonSetWorkload) PodSelector() string {
+	return metav1.FormatLabelSelector(w.obj.Spec.Selector)
+}
+func (w *daemonSetWorkload) ContainerName() string {
+	return w.obj.Spec.Template.Spec.Containers[0].Name
+}
+
+type replicaSetWorkload struct{ obj *appsv1.ReplicaSet }
+
+func (w *replicaSetWorkload) Create(ctx context.Context, c kubernetes.Interface, ns string) error {
+	obj, err := retry.CreateK8sObjectWithRetry(ctx, func() (interface{}, error) {
+		return c.AppsV1().ReplicaSets(ns).Create(w.obj)
+	}, func() (interface{}, error) {
+		return c.AppsV1().ReplicaSets(ns).Get(w.obj.Name, metav1.GetOptions{})
+	})
+	if err != nil {
+		return err
+	}
+	w.obj = obj.(*appsv1.ReplicaSet)
+	return nil
+}
+func (w *replicaSetWorkload) Delete(ctx context.Context, c kubernetes.Interface, ns string) error {
+	return retry.DeleteK8sObjectWithRetry(ctx, func() error {
+		return c.AppsV1().ReplicaSets(ns).Delete(w.obj.Name, nil)
+	})
+}
+func (w *replicaSetWorkload) SetCrashLoop() {
+	w.obj.Spec.Template.Spec.Containers[0].Command = []string{"echo"}
+}
+func (w *replicaSetWorkload) PodSelector() string {
+	return metav1.FormatLabelSelector(w.obj.Spec.Selector)
+}
+func (w *replicaSetWorkload) ContainerName() string {
+	return w.obj.Spec.Template.Spec.Containers[0].Name
+}
+
+var _ = Describe("Monitoring workload kinds", func() {
+	var c kubernetes.Interface
+	var ns string
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		c = kubernetes.NewForConfigOrDie(clusterConfigOrDie())
+		ns = createNsOrDie(c.CoreV1(), "test-monitoring-kind-")
+	})
+
+	AfterEach(func() {
+		deleteNsWithRetry(ctx, c, ns)
+	})
+
+	for _, kind := range []string{"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet"} {
+		kind := kind
+
+		Context(kind, func() {
+			var workload workloadUnderTest
+
+			BeforeEach(func() {
+				var err error
+				workload, err = newWorkloadUnderTest(kind)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			// Exercise the Delete half of workloadUnderTest too: deleting the
+			// owning object should eventually take its pods with it,
+			// regardless of kind. deleteNsWithRetry below would clean these
+			// up anyway, but that doesn't tell us Delete/PodSelector
+			// actually work for each kind.
+			AfterEach(func() {
+				if workload == nil {
+					return
+				}
+
+				selector := workload.PodSelector()
+				Expect(workload.Delete(ctx, c, ns)).To(Succeed())
+
+				Eventually(func() (int, error) {
+					pods, err := c.CoreV1().Pods(ns).List(metav1.ListOptions{LabelSelector: selector})
+					if err != nil {
+						return -1, err
+					}
+					return len(pods.Items), nil
+				}, "2m", "5s").Should(Equal(0))
+			})
+
+			// This test makes a query to the prometheus API to check if
+			// prometheus is monitoring the container launched by the test,
+			// regardless of the workload kind that owns it.
+			It("should monitor container", func() {
+				Expect(workload.Create(ctx, c, ns)).To(Succeed())
+
+				var series []Series
+				Eventually(func() ([]Series, error) {
+					selector := fmt.Sprintf("kube_pod_container_info{namespace=\"%s\",container=\"%s\"}", ns, workload.ContainerName())
+					params := map[string]string{"match[]": selector}
+					resultRaw, err := retry.ProxyGetWithRetry(ctx, func() ([]byte, error) {
+						return c.CoreV1().Services("kubeprod").ProxyGet("http", "prometheus", "9090", "api/v1/series", params).DoRaw()
+					})
+					if err != nil {
+						return nil, err
+					}
+
+					resp := promResponse{}
+					json.Unmarshal(resultRaw, &resp)
+					json.Unmarshal(resp.Data, &series)
+
+					return series, err
+				}, "20m", "5s").
+					Should(WithTransform(countSeries, BeNumerically(">", 0)))
+
+				Expect(series[0].Container).To(Equal(workload.ContainerName()))
+				Expect(series[0].Namespace).To(Equal(ns))
+			})
+
+			// In this test we configure the container such that it enters a
+			// CrashLoop. The test passes if prometheus reports that the
+			// container has entered a CrashLoop, regardless of the
+			// workload kind that owns it.
+			It("should detect the crashing container", func() {
+				workload.SetCrashLoop()
+				Expect(workload.Create(ctx, c, ns)).To(Succeed())
+
+				var series []Series
+				Eventually(func() ([]Series, error) {
+					selector := fmt.Sprintf("ALERTS{namespace=\"%s\",container=\"%s\",alertname=\"%s\",alertstate=\"firing\"}", ns, workload.ContainerName(), am_alertname)
+					params := map[string]string{"match[]": selector}
+					resultRaw, err := retry.ProxyGetWithRetry(ctx, func() ([]byte, error) {
+						return c.CoreV1().Services("kubeprod").ProxyGet("http", "prometheus", "9090", "api/v1/series", params).DoRaw()
+					})
+					if err != nil {
+						return nil, err
+					}
+
+					resp := promResponse{}
+					json.Unmarshal(resultRaw, &resp)
+					json.Unmarshal(resp.Data, &series)
+
+					return series, err
+				}, "20m", "5s").
+					Should(WithTransform(countSeries, BeNumerically(">", 0)))
+
+				Expect(series[0].Container).To(Equal(workload.ContainerName()))
+				Expect(series[0].Namespace).To(Equal(ns))
+				Expect(series[0].Alertname).To(Equal(am_alertname))
+			})
+		})
+	}
+})